@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/chromedp"
+)
+
+var (
+	// 抓 CSS 的 url(...) 與 @import；正則本身也會匹配 data: URI，由呼叫端略過
+	reCSSURL    = regexp.MustCompile(`url\(\s*['"]?([^'")]+)['"]?\s*\)`)
+	reCSSImport = regexp.MustCompile(`@import\s+(?:url\()?['"]?([^'")]+)['"]?\)?`)
+)
+
+// assetCache 是內容 SHA-1 前綴 -> 本地相對路徑的下載去重紀錄。單一 assetCache 會在整個
+// crawl（mirror/sitemap 多 worker 並行渲染許多頁面）期間共用，確保全站共用的資產
+// （logo、共用 CSS/JS 等）只下載一次，而不是每個頁面各自重算重下載一次。
+type assetCache struct {
+	mu   sync.Mutex
+	seen map[string]string
+}
+
+func newAssetCache() *assetCache {
+	return &assetCache{seen: map[string]string{}}
+}
+
+func (c *assetCache) get(hash string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rel, ok := c.seen[hash]
+	return rel, ok
+}
+
+func (c *assetCache) put(hash, rel string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen[hash] = rel
+}
+
+var (
+	assetCacheOnce sync.Once
+	assetCacheInst *assetCache
+)
+
+// sharedAssetCache 回傳整個執行期間共用的資產去重快取，只在第一次呼叫時建立。
+func sharedAssetCache() *assetCache {
+	assetCacheOnce.Do(func() {
+		assetCacheInst = newAssetCache()
+	})
+	return assetCacheInst
+}
+
+// collectAssetRefsJS 蒐集 DOM 上 img/source/script/preload|icon link 的原始屬性值與瀏覽器解析後的絕對網址。
+// 回傳 [][原始屬性值, 絕對網址] 這樣才能在 html 字串中準確找到要替換的原文。
+const collectAssetRefsJS = `
+(() => {
+  const refs = [];
+  const push = (raw, abs) => { if (raw && abs) refs.push([raw, abs]); };
+  document.querySelectorAll('img[src]').forEach(el => push(el.getAttribute('src'), el.src));
+  document.querySelectorAll('source[src]').forEach(el => push(el.getAttribute('src'), el.src));
+  document.querySelectorAll('script[src]').forEach(el => push(el.getAttribute('src'), el.src));
+  document.querySelectorAll('link[rel~="preload"][href], link[rel~="icon"][href], link[rel~="shortcut"][href]')
+    .forEach(el => push(el.getAttribute('href'), el.href));
+  document.querySelectorAll('img[srcset], source[srcset]').forEach(el => {
+    el.getAttribute('srcset').split(',').forEach(part => {
+      const raw = part.trim().split(/\s+/)[0];
+      if (raw) {
+        try { push(raw, new URL(raw, document.baseURI).href); } catch (e) {}
+      }
+    });
+  });
+  return refs;
+})()
+`
+
+// collectInlineStylesJS 蒐集 style 屬性與 <style> 內文，供後續用正則找出其中的 url(...)。
+const collectInlineStylesJS = `
+(() => {
+  const styles = [];
+  document.querySelectorAll('[style]').forEach(el => styles.push(el.getAttribute('style')));
+  document.querySelectorAll('style').forEach(el => styles.push(el.textContent));
+  return styles;
+})()
+`
+
+// downloadPageAssets 走訪已渲染的 DOM，把圖片、腳本、字型與 CSS 引用的資產下載到 assetDir，
+// 並把 html 裡對應的原始網址改寫成本地相對路徑。回傳改寫後的 html 與新下載的資產數量。
+func downloadPageAssets(ctx context.Context, pageURL string, html string, assetDir string) (string, int, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return html, 0, err
+	}
+
+	var domRefs [][]string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(collectAssetRefsJS, &domRefs)); err != nil {
+		return html, 0, fmt.Errorf("collect asset refs: %w", err)
+	}
+
+	var styles []string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(collectInlineStylesJS, &styles)); err != nil {
+		return html, 0, fmt.Errorf("collect inline styles: %w", err)
+	}
+
+	type ref struct {
+		original string
+		absolute string
+	}
+	refs := make([]ref, 0, len(domRefs))
+	for _, pair := range domRefs {
+		if len(pair) != 2 {
+			continue
+		}
+		refs = append(refs, ref{original: pair[0], absolute: pair[1]})
+	}
+	for _, style := range styles {
+		for _, m := range reCSSURL.FindAllStringSubmatch(style, -1) {
+			refs = append(refs, ref{original: m[1], absolute: resolveRef(m[1], base)})
+		}
+	}
+
+	cache := sharedAssetCache()
+	replacements := map[string]string{} // 原文字串 -> 相對路徑
+	saved := 0
+
+	for _, r := range refs {
+		if r.original == "" || r.absolute == "" || strings.HasPrefix(r.original, "data:") {
+			continue
+		}
+		if _, done := replacements[r.original]; done {
+			continue
+		}
+		rel, isNew, err := downloadAsset(r.absolute, assetDir, cache)
+		if err != nil {
+			fmt.Printf("⚠️  下載資產失敗 %s: %v\n", r.absolute, err)
+			continue
+		}
+		replacements[r.original] = rel
+		if isNew {
+			saved++
+		}
+	}
+
+	processed := html
+	for original, rel := range replacements {
+		processed = strings.ReplaceAll(processed, original, rel)
+	}
+
+	return processed, saved, nil
+}
+
+// downloadAsset 下載單一資產，依內容 SHA-1 去重，CSS 檔案會遞迴下載其引用的子資產並改寫成相對路徑。
+func downloadAsset(rawURL string, assetDir string, cache *assetCache) (string, bool, error) {
+	data, contentType, err := fetchBytes(rawURL)
+	if err != nil {
+		return "", false, err
+	}
+
+	h := sha1.Sum(data)
+	hashPrefix := fmt.Sprintf("%x", h)[:12]
+	if existing, ok := cache.get(hashPrefix); ok {
+		return existing, false, nil
+	}
+
+	ext := assetExt(rawURL, contentType)
+	if ext == ".css" {
+		data = rewriteCSSAssetRefs(data, rawURL, assetDir, cache)
+	}
+
+	filename := fmt.Sprintf("asset_%s%s", hashPrefix, ext)
+	if err := os.MkdirAll(assetDir, 0755); err != nil {
+		return "", false, err
+	}
+	if err := os.WriteFile(filepath.Join(assetDir, filename), data, 0644); err != nil {
+		return "", false, err
+	}
+
+	rel := filepath.ToSlash(filepath.Join("assets", filename))
+	cache.put(hashPrefix, rel)
+	return rel, true, nil
+}
+
+// rewriteCSSAssetRefs 解析 CSS 內的 url(...) 與 @import，遞迴下載子資產，並把引用改成同層相對檔名。
+func rewriteCSSAssetRefs(data []byte, cssURL string, assetDir string, cache *assetCache) []byte {
+	base, err := url.Parse(cssURL)
+	if err != nil {
+		return data
+	}
+
+	resolveAndDownload := func(raw string) string {
+		if strings.HasPrefix(raw, "data:") {
+			return raw
+		}
+		abs := resolveRef(raw, base)
+		if abs == "" {
+			return raw
+		}
+		rel, _, err := downloadAsset(abs, assetDir, cache)
+		if err != nil {
+			return raw
+		}
+		return filepath.Base(rel)
+	}
+
+	content := string(data)
+	content = reCSSURL.ReplaceAllStringFunc(content, func(m string) string {
+		sub := reCSSURL.FindStringSubmatch(m)
+		if len(sub) != 2 {
+			return m
+		}
+		return fmt.Sprintf("url(%s)", resolveAndDownload(sub[1]))
+	})
+	content = reCSSImport.ReplaceAllStringFunc(content, func(m string) string {
+		sub := reCSSImport.FindStringSubmatch(m)
+		if len(sub) != 2 {
+			return m
+		}
+		return fmt.Sprintf("@import url(%s)", resolveAndDownload(sub[1]))
+	})
+	return []byte(content)
+}
+
+// resolveRef 把可能是相對路徑的 raw 解析成相對於 base 的絕對網址，失敗則回傳空字串。
+func resolveRef(raw string, base *url.URL) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	if u.IsAbs() {
+		return u.String()
+	}
+	return base.ResolveReference(u).String()
+}
+
+// assetExt 優先用網址路徑的副檔名，拿不到才退回用 Content-Type 猜測（沿用 guessExt）。
+func assetExt(rawURL string, contentType string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		if ext := path.Ext(u.Path); ext != "" {
+			return ext
+		}
+	}
+	mimeType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	return guessExt(mimeType)
+}
+
+// fetchBytes 下載 rawURL 的完整內容與 Content-Type，供資產流水線做雜湊與副檔名判斷。
+func fetchBytes(rawURL string) ([]byte, string, error) {
+	resp, err := sharedHTTPClient().Get(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
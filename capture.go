@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/device"
+)
+
+// devicePresets 把 DEVICE 環境變數接受的名字（不分大小寫）對應到 chromedp/device 裡的預設值。
+var devicePresets = map[string]device.Info{
+	"iphone11":     device.IPhone11.Device(),
+	"iphone12":     device.IPhone12.Device(),
+	"iphone13":     device.IPhone13.Device(),
+	"iphone14":     device.IPhone14.Device(),
+	"iphone15":     device.IPhone15.Device(),
+	"iphone15pro":  device.IPhone15Pro.Device(),
+	"ipad":         device.IPad.Device(),
+	"ipadmini":     device.IPadMini.Device(),
+	"ipadpro":      device.IPadPro.Device(),
+	"ipadpro11":    device.IPadPro11.Device(),
+	"pixel3":       device.Pixel3.Device(),
+	"pixel4":       device.Pixel4.Device(),
+	"pixel5":       device.Pixel5.Device(),
+	"galaxys8":     device.GalaxyS8.Device(),
+	"galaxys9":     device.GalaxyS9.Device(),
+	"galaxynoteii": device.GalaxyNoteII.Device(),
+}
+
+// deviceEmulationAction 依 DEVICE（具名預設）或 VIEWPORT_WIDTH/HEIGHT/DPR/UA 組出裝置模擬 action，都沒設定就回傳 nil。
+func deviceEmulationAction() chromedp.Action {
+	if name := os.Getenv("DEVICE"); name != "" {
+		info, ok := devicePresets[strings.ToLower(name)]
+		if !ok {
+			log.Printf("unknown DEVICE preset %q, ignoring", name)
+		} else {
+			return chromedp.Emulate(info)
+		}
+	}
+
+	width := envInt("VIEWPORT_WIDTH", 0)
+	height := envInt("VIEWPORT_HEIGHT", 0)
+	if width == 0 || height == 0 {
+		return nil
+	}
+	dpr := envFloat("VIEWPORT_DPR", 1)
+	ua := os.Getenv("VIEWPORT_UA")
+
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := chromedp.EmulateViewport(int64(width), int64(height), chromedp.EmulateScale(dpr)).Do(ctx); err != nil {
+			return err
+		}
+		if ua == "" {
+			return nil
+		}
+		return emulation.SetUserAgentOverride(ua).Do(ctx)
+	})
+}
+
+func envFloat(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+// captureScreenshot 在目前頁面拍一張全頁 PNG 截圖，存到 outPath。
+func captureScreenshot(ctx context.Context, outPath string) error {
+	var buf []byte
+	// quality 100 時 chromedp 輸出 PNG，低於 100 則輸出 JPEG
+	if err := chromedp.Run(ctx, chromedp.FullScreenshot(&buf, 100)); err != nil {
+		return fmt.Errorf("full screenshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, buf, 0644)
+}
+
+// pdfOptions 收斂 CAPTURE_PDF 相關的紙張、邊界與背景列印設定。
+type pdfOptions struct {
+	paperWidth      float64
+	paperHeight     float64
+	marginTop       float64
+	marginBottom    float64
+	marginLeft      float64
+	marginRight     float64
+	printBackground bool
+}
+
+// loadPDFOptions 從 .env 讀出列印參數，預設為美式 Letter、0.4 吋邊界、列印背景。
+func loadPDFOptions() pdfOptions {
+	return pdfOptions{
+		paperWidth:      envFloat("PDF_PAPER_WIDTH", 8.5),
+		paperHeight:     envFloat("PDF_PAPER_HEIGHT", 11),
+		marginTop:       envFloat("PDF_MARGIN_TOP", 0.4),
+		marginBottom:    envFloat("PDF_MARGIN_BOTTOM", 0.4),
+		marginLeft:      envFloat("PDF_MARGIN_LEFT", 0.4),
+		marginRight:     envFloat("PDF_MARGIN_RIGHT", 0.4),
+		printBackground: envBool("PDF_PRINT_BACKGROUND", true),
+	}
+}
+
+// capturePDF 把目前頁面印成 PDF，存到 outPath。
+func capturePDF(ctx context.Context, outPath string, opts pdfOptions) error {
+	var buf []byte
+	if err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		data, _, err := page.PrintToPDF().
+			WithPaperWidth(opts.paperWidth).
+			WithPaperHeight(opts.paperHeight).
+			WithMarginTop(opts.marginTop).
+			WithMarginBottom(opts.marginBottom).
+			WithMarginLeft(opts.marginLeft).
+			WithMarginRight(opts.marginRight).
+			WithPrintBackground(opts.printBackground).
+			Do(ctx)
+		if err != nil {
+			return err
+		}
+		buf = data
+		return nil
+	})); err != nil {
+		return fmt.Errorf("print to pdf: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, buf, 0644)
+}
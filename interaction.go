@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"gopkg.in/yaml.v3"
+)
+
+// interactionStep 是互動腳本裡的一個步驟，欄位依 Type 而定，未用到的留空即可。
+type interactionStep struct {
+	Type      string `yaml:"type" json:"type"`
+	Selector  string `yaml:"selector,omitempty" json:"selector,omitempty"`
+	Repeat    bool   `yaml:"repeat_until_gone,omitempty" json:"repeat_until_gone,omitempty"`
+	Script    string `yaml:"script,omitempty" json:"script,omitempty"`
+	Sleep     string `yaml:"sleep,omitempty" json:"sleep,omitempty"`
+	Timeout   string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	StableFor int    `yaml:"stable_ticks,omitempty" json:"stable_ticks,omitempty"`
+}
+
+// interactionScript 是從 INTERACTION_SCRIPT 載入的整份前置互動腳本。
+type interactionScript struct {
+	Steps []interactionStep `yaml:"steps" json:"steps"`
+}
+
+// loadInteractionScript 依副檔名以 YAML 或 JSON 解析互動腳本檔案。
+func loadInteractionScript(path string) (*interactionScript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read interaction script: %w", err)
+	}
+
+	var script interactionScript
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &script)
+	default: // .yaml / .yml 及其他一律當 YAML 處理
+		err = yaml.Unmarshal(data, &script)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse interaction script: %w", err)
+	}
+	return &script, nil
+}
+
+// loadInteractionActions 讀取並解析 path 指向的互動腳本，回傳可直接塞進 chromedp.Run 的 action 序列。
+func loadInteractionActions(path string) ([]chromedp.Action, error) {
+	script, err := loadInteractionScript(path)
+	if err != nil {
+		return nil, err
+	}
+	return buildInteractionActions(script)
+}
+
+// scriptNeedsDialogAutoAccept 回報腳本裡是否有 accept_dialog 步驟。
+// fetchRenderedHTML 用這個判斷是否要把對話框監聽器提早註冊到 chromedp.Navigate 之前，
+// 而不是照腳本原本的順序排在等 #app 之後才註冊（見 chunk0-4 review）。
+func scriptNeedsDialogAutoAccept(script *interactionScript) bool {
+	for _, step := range script.Steps {
+		if step.Type == "accept_dialog" {
+			return true
+		}
+	}
+	return false
+}
+
+// dialogAutoAcceptAction 註冊一個監聽器，自動接受頁面跳出的 alert/confirm/beforeunload 對話框。
+// 必須在 chromedp.Navigate 之前註冊：原生對話框會卡住 JS 執行緒，若監聽器排在
+// WaitReady/WaitVisible 之後才註冊，頁面載入時彈出的對話框會讓 #app 永遠等不到，
+// 一路卡到 context timeout 才結束。
+func dialogAutoAcceptAction() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		chromedp.ListenTarget(ctx, func(ev interface{}) {
+			if _, ok := ev.(*page.EventJavascriptDialogOpening); ok {
+				go func() {
+					if err := chromedp.Run(ctx, page.HandleJavaScriptDialog(true)); err != nil {
+						log.Printf("accept_dialog: %v", err)
+					}
+				}()
+			}
+		})
+		return nil
+	})
+}
+
+// buildInteractionActions 把腳本步驟轉成 chromedp action 序列，插在等待 #app 之後、取 OuterHTML 之前執行。
+func buildInteractionActions(script *interactionScript) ([]chromedp.Action, error) {
+	actions := make([]chromedp.Action, 0, len(script.Steps))
+	for i, step := range script.Steps {
+		action, err := buildInteractionAction(step)
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s): %w", i+1, step.Type, err)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+func buildInteractionAction(step interactionStep) (chromedp.Action, error) {
+	switch step.Type {
+	case "accept_dialog":
+		// 監聽器已經由 fetchRenderedHTML 透過 dialogAutoAcceptAction 提早註冊在
+		// chromedp.Navigate 之前，這裡保留步驟位置只是讓腳本讀起來仍照原本順序，不需要再做事。
+		return chromedp.ActionFunc(func(ctx context.Context) error { return nil }), nil
+
+	case "click":
+		if step.Selector == "" {
+			return nil, fmt.Errorf("click requires a selector")
+		}
+		if step.Repeat {
+			return chromedp.ActionFunc(func(ctx context.Context) error {
+				for {
+					var exists bool
+					if err := chromedp.Evaluate(fmt.Sprintf(`document.querySelector(%q) !== null`, step.Selector), &exists).Do(ctx); err != nil {
+						return err
+					}
+					if !exists {
+						return nil
+					}
+					if err := chromedp.Click(step.Selector, chromedp.ByQuery).Do(ctx); err != nil {
+						return nil // 元素可能在檢查與點擊之間消失了，視為「已經沒了」而結束
+					}
+					if err := waitForNetworkIdle(500 * time.Millisecond).Do(ctx); err != nil {
+						return nil
+					}
+				}
+			}), nil
+		}
+		return chromedp.Click(step.Selector, chromedp.ByQuery), nil
+
+	case "scroll_to_bottom":
+		stableTicks := step.StableFor
+		if stableTicks <= 0 {
+			stableTicks = 3
+		}
+		return chromedp.ActionFunc(func(ctx context.Context) error {
+			lastHeight := -1
+			stable := 0
+			for stable < stableTicks {
+				if err := chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight)`, nil).Do(ctx); err != nil {
+					return err
+				}
+				time.Sleep(300 * time.Millisecond)
+				var height int
+				if err := chromedp.Evaluate(`document.body.scrollHeight`, &height).Do(ctx); err != nil {
+					return err
+				}
+				if height == lastHeight {
+					stable++
+				} else {
+					stable = 0
+					lastHeight = height
+				}
+			}
+			return nil
+		}), nil
+
+	case "wait_selector":
+		if step.Selector == "" {
+			return nil, fmt.Errorf("wait_selector requires a selector")
+		}
+		timeout := parseDurationOr(step.Timeout, 10*time.Second)
+		return chromedp.ActionFunc(func(ctx context.Context) error {
+			wctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return chromedp.WaitVisible(step.Selector, chromedp.ByQuery).Do(wctx)
+		}), nil
+
+	case "eval":
+		if step.Script == "" {
+			return nil, fmt.Errorf("eval requires a script")
+		}
+		return chromedp.Evaluate(step.Script, nil), nil
+
+	case "sleep":
+		return chromedp.Sleep(parseDurationOr(step.Sleep, 1*time.Second)), nil
+
+	default:
+		return nil, fmt.Errorf("unknown step type %q", step.Type)
+	}
+}
+
+func parseDurationOr(s string, def time.Duration) time.Duration {
+	if s == "" {
+		return def
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d
+	}
+	return def
+}
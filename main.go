@@ -42,11 +42,46 @@ func main() {
 		log.Fatal("TARGET_URL is not set in .env file")
 	}
 
+	transportCfg := loadTransportConfig()
+
+	// mirror 模式：整站並行爬取＋落地＋連結改寫，取代單頁渲染流程
+	if envBool("MIRROR_MODE", false) {
+		cfg := loadMirrorConfig()
+		allocOpts := append(chromedp.DefaultExecAllocatorOptions[:],
+			chromedp.Flag("headless", true),
+			chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"),
+		)
+		allocOpts = append(allocOpts, transportCfg.allocatorOptions()...)
+		allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), allocOpts...)
+		defer cancel()
+		if err := runMirror(allocCtx, targetURL, cfg); err != nil {
+			log.Fatalf("mirror failed: %v", err)
+		}
+		return
+	}
+
+	// sitemap 模式：優先消費既有 sitemap.xml，否則 BFS 爬取同源連結，完成後輸出 sitemap.xml/.txt
+	if envBool("SITEMAP_MODE", false) {
+		cfg := loadSitemapConfig()
+		allocOpts := append(chromedp.DefaultExecAllocatorOptions[:],
+			chromedp.Flag("headless", true),
+			chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"),
+		)
+		allocOpts = append(allocOpts, transportCfg.allocatorOptions()...)
+		allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), allocOpts...)
+		defer cancel()
+		if err := runSitemapCrawl(allocCtx, targetURL, cfg); err != nil {
+			log.Fatalf("sitemap crawl failed: %v", err)
+		}
+		return
+	}
+
 	// 创建 chromedp 上下文
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.Flag("headless", true),
 		chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"),
 	)
+	opts = append(opts, transportCfg.allocatorOptions()...)
 	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
 	defer cancel()
 
@@ -243,19 +278,47 @@ func waitForNetworkIdle(idleFor time.Duration) chromedp.Action {
 	})
 }
 
-func grabRenderedHTML(ctx context.Context, url string, outFile string) error {
+// fetchRenderedHTML 導航到 url 並等待渲染完成，回傳 doctype + outerHTML（確保是「JS 後」的 DOM）。
+// 若設定了 INTERACTION_SCRIPT，會在抓 OuterHTML 之前先跑過腳本裡的每個互動步驟。
+func fetchRenderedHTML(ctx context.Context, url string) (string, error) {
 	var html string
-	return chromedp.Run(ctx,
-		network.Enable(),
+
+	// 先解析互動腳本：如果裡面有 accept_dialog，對話框監聽器必須搶在 chromedp.Navigate 之前
+	// 註冊，不能照腳本原本的順序排在 WaitReady/WaitVisible 之後，否則頁面載入時彈出的原生
+	// 對話框會卡住 JS 執行緒，讓 #app 永遠等不到，一路卡到 context timeout 才結束。
+	var interactionSteps []chromedp.Action
+	needsDialogAutoAccept := false
+	if scriptPath := os.Getenv("INTERACTION_SCRIPT"); scriptPath != "" {
+		if script, err := loadInteractionScript(scriptPath); err != nil {
+			log.Printf("interaction script %s skipped: %v", scriptPath, err)
+		} else if steps, err := buildInteractionActions(script); err != nil {
+			log.Printf("interaction script %s skipped: %v", scriptPath, err)
+		} else {
+			interactionSteps = steps
+			needsDialogAutoAccept = scriptNeedsDialogAutoAccept(script)
+		}
+	}
+
+	actions := []chromedp.Action{}
+	if emulate := deviceEmulationAction(); emulate != nil {
+		actions = append(actions, emulate)
+	}
+	transportCfg := loadTransportConfig()
+	actions = append(actions, network.Enable(), transportCfg.applyAction())
+	if needsDialogAutoAccept {
+		actions = append(actions, dialogAutoAcceptAction())
+	}
+	actions = append(actions,
 		chromedp.Navigate(url),
 		chromedp.WaitReady("body", chromedp.ByQuery),   // DOM 就绪
 		waitForNetworkIdle(1*time.Second),              // 网络空闲 1s（可按页面特性调大）
 		chromedp.WaitVisible(`#app`, chromedp.ByQuery), // 你的 Vue 根节点
 		// 如果你还要等某块动态内容出现，比如 .category-tab-item：
 		// chromedp.WaitVisible(`.category-tab-item`, chromedp.ByQuery),
+	)
+	actions = append(actions, interactionSteps...)
 
-		// 拿 doctype + outerHTML（确保是“JS 后”的 DOM）
-		chromedp.Evaluate(`
+	actions = append(actions, chromedp.Evaluate(`
 (() => {
   const dt = document.doctype;
   const doctype = dt ? "<!DOCTYPE " + dt.name
@@ -265,23 +328,75 @@ func grabRenderedHTML(ctx context.Context, url string, outFile string) error {
     + ">\n" : "";
   return doctype + document.documentElement.outerHTML;
 })()
-`, &html),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			pretty := gohtml.Format(html)
-			assetDir := filepath.Join("output", "assets")
-			// 先把 base64 data: 圖片抽出→落地→替換引用
-			if err := os.MkdirAll(assetDir, 0755); err != nil {
-				log.Fatalf("mkdir assets failed: %v", err)
-			}
-			processedHTML, saved, err := extractAndReplaceDataURIs(html, assetDir)
-			if err != nil {
-				log.Fatalf("extract/replace data URIs failed: %v", err)
-			}
-			fmt.Printf("🖼️  另存 base64 圖片 %d 張到 %s/\n", saved, assetDir)
-			pretty = gohtml.Format(processedHTML)
-			return os.WriteFile(outFile, []byte(pretty), 0644)
-		}),
-	)
+`, &html))
+
+	err := chromedp.Run(ctx, actions...)
+	return html, err
+}
+
+// renderPage 是渲染單一頁面的共用後處理流程：cookie 落地、data URI 抽取、外部資產下載，
+// 以及（如果有設定）截圖／PDF 擷取。回傳處理後但尚未排版/寫檔的 HTML，
+// 讓 grabRenderedHTML 與 mirrorPage 可以共用同一條流水線，各自接著做自己的落地前處理
+// （mirror 模式還要改寫站內連結），避免 mirror 模式漏掉 cookie 落地與截圖/PDF（chunk0-6、chunk0-5）。
+func renderPage(ctx context.Context, url string, outFile string) (string, error) {
+	html, err := fetchRenderedHTML(ctx, url)
+	if err != nil {
+		return "", err
+	}
+
+	if err := loadTransportConfig().persistCookies(ctx); err != nil {
+		log.Printf("persist cookie jar failed: %v", err)
+	}
+
+	assetDir := filepath.Join("output", "assets")
+	// 先把 base64 data: 圖片抽出→落地→替換引用
+	if err := os.MkdirAll(assetDir, 0755); err != nil {
+		return "", fmt.Errorf("mkdir assets failed: %w", err)
+	}
+	processedHTML, saved, err := extractAndReplaceDataURIs(html, assetDir)
+	if err != nil {
+		return "", fmt.Errorf("extract/replace data URIs failed: %w", err)
+	}
+	fmt.Printf("🖼️  另存 base64 圖片 %d 張到 %s/\n", saved, assetDir)
+
+	processedHTML, assetsSaved, err := downloadPageAssets(ctx, url, processedHTML, assetDir)
+	if err != nil {
+		log.Printf("download page assets failed: %v", err)
+	} else {
+		fmt.Printf("📦 另存外部資產 %d 個到 %s/\n", assetsSaved, assetDir)
+	}
+
+	outputDir := filepath.Dir(outFile)
+	if envBool("CAPTURE_SCREENSHOT", false) {
+		shotPath := filepath.Join(outputDir, "screenshot.png")
+		if err := captureScreenshot(ctx, shotPath); err != nil {
+			log.Printf("capture screenshot failed: %v", err)
+		} else {
+			fmt.Printf("📸 已儲存截圖到 %s\n", shotPath)
+		}
+	}
+	if envBool("CAPTURE_PDF", false) {
+		pdfPath := filepath.Join(outputDir, "page.pdf")
+		if err := capturePDF(ctx, pdfPath, loadPDFOptions()); err != nil {
+			log.Printf("capture pdf failed: %v", err)
+		} else {
+			fmt.Printf("🖨️  已儲存 PDF 到 %s\n", pdfPath)
+		}
+	}
+
+	return processedHTML, nil
+}
+
+func grabRenderedHTML(ctx context.Context, url string, outFile string) error {
+	processedHTML, err := renderPage(ctx, url, outFile)
+	if err != nil {
+		return err
+	}
+	pretty := gohtml.Format(processedHTML)
+	if err := os.MkdirAll(filepath.Dir(outFile), 0755); err != nil {
+		return fmt.Errorf("mkdir %s: %w", filepath.Dir(outFile), err)
+	}
+	return os.WriteFile(outFile, []byte(pretty), 0644)
 }
 
 // 返回：處理後 HTML、保存數量、錯誤
@@ -367,9 +482,8 @@ func downloadFile(url, filename string) error {
 		return err
 	}
 
-	// 发送 HTTP 请求
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
+	// 发送 HTTP 请求（套用 HTTP_PROXY_URL / BASIC_AUTH_* / EXTRA_HEADERS）
+	resp, err := sharedHTTPClient().Get(url)
 	if err != nil {
 		return err
 	}
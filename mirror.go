@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/yosssi/gohtml"
+)
+
+// collectLinksJS 蒐集頁面上 <a href> 指向的其他網址，當作爬取佇列（與 sitemap 項目）的候選頁面。
+// link/script/img 這類資產參照交給 downloadPageAssets 自己的 collectAssetRefsJS 處理，
+// 不應該把圖片、腳本、CSS 網址當成「頁面」丟進 chromedp 去 Navigate+等 #app（見 chunk0-1 review）。
+// 回傳 [原始 href 屬性文字, 瀏覽器解析後的絕對網址]，和 collectAssetRefsJS 同一個套路：
+// outerHTML 保留的是原始屬性文字（相對路徑還是相對路徑，不會被解析成絕對網址），
+// 改寫連結時必須拿原始文字去比對字串，拿絕對網址去比對只對本來就是絕對路徑的連結有效。
+const collectLinksJS = `
+(() => {
+  const refs = [];
+  const seen = new Set();
+  document.querySelectorAll('a[href]').forEach(a => {
+    const raw = a.getAttribute('href');
+    if (raw && !seen.has(raw)) {
+      seen.add(raw);
+      refs.push([raw, a.href]);
+    }
+  });
+  return refs;
+})()
+`
+
+// mirrorConfig 控制 mirror 模式的爬取行為，可由環境變數或 CLI flag 覆寫。
+type mirrorConfig struct {
+	workers    int
+	sameDomain bool
+	allowHosts map[string]bool
+	maxDepth   int
+}
+
+// loadMirrorConfig 從 .env 讀出預設值，再用 CLI flag 覆寫（flag 優先）。
+func loadMirrorConfig() mirrorConfig {
+	cfg := mirrorConfig{
+		workers:    envInt("WORKER_COUNT", 4),
+		sameDomain: envBool("SAME_DOMAIN", true),
+		allowHosts: splitCSVSet(os.Getenv("ALLOW_HOSTS")),
+		maxDepth:   envInt("MAX_DEPTH", 3),
+	}
+
+	workers := flag.Int("workers", cfg.workers, "number of concurrent mirror workers (4-8 recommended)")
+	sameDomain := flag.Bool("same-domain", cfg.sameDomain, "restrict crawl to the target's own domain")
+	allowHosts := flag.String("allow-hosts", os.Getenv("ALLOW_HOSTS"), "comma separated extra hosts allowed during crawl")
+	maxDepth := flag.Int("max-depth", cfg.maxDepth, "maximum link-following depth from the start URL")
+	flag.Parse()
+
+	cfg.workers = *workers
+	cfg.sameDomain = *sameDomain
+	cfg.allowHosts = splitCSVSet(*allowHosts)
+	cfg.maxDepth = *maxDepth
+	return cfg
+}
+
+func envInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+func envBool(key string, def bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+func envOr(key string, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func splitCSVSet(v string) map[string]bool {
+	set := map[string]bool{}
+	for _, h := range strings.Split(v, ",") {
+		h = strings.TrimSpace(h)
+		if h != "" {
+			set[h] = true
+		}
+	}
+	return set
+}
+
+// mirrorJob 是 worker pool 裡的一個爬取工作單位。
+type mirrorJob struct {
+	url   string
+	depth int
+}
+
+// runMirror 從 startURL 開始，用固定大小的 worker pool 並行渲染整個站台，
+// 依照原始 URL 路徑結構落地到 output/ 下，並改寫站內連結為相對路徑。
+func runMirror(allocCtx context.Context, startURL string, cfg mirrorConfig) error {
+	start, err := url.Parse(startURL)
+	if err != nil {
+		return fmt.Errorf("parse start URL: %w", err)
+	}
+
+	var visited sync.Map // normalized URL -> ""（只當作已排入佇列的標記）
+	jobs := make(chan mirrorJob, 256)
+	var wg sync.WaitGroup
+	var pending sync.WaitGroup
+	var mu sync.Mutex // guards errs
+	var errs []error
+
+	enqueue := func(raw string, depth int) {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		if cfg.sameDomain && u.Host != start.Host && !cfg.allowHosts[u.Host] {
+			return
+		}
+		if depth > cfg.maxDepth {
+			return
+		}
+		norm := normalizeURL(u)
+		if _, loaded := visited.LoadOrStore(norm, ""); loaded {
+			return
+		}
+		pending.Add(1)
+		jobs <- mirrorJob{url: u.String(), depth: depth}
+	}
+
+	for i := 0; i < cfg.workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for job := range jobs {
+				func() {
+					defer pending.Done()
+					tabCtx, cancel := chromedp.NewContext(allocCtx)
+					defer cancel()
+					tabCtx, cancel2 := context.WithTimeout(tabCtx, 60*time.Second)
+					defer cancel2()
+
+					outFile := mirrorPathFor(start, job.url)
+					links, err := mirrorPage(tabCtx, job.url, outFile)
+					if err != nil {
+						log.Printf("[mirror worker %d] %s: %v", worker, job.url, err)
+						mu.Lock()
+						errs = append(errs, err)
+						mu.Unlock()
+						return
+					}
+					fmt.Printf("[mirror] saved %s -> %s (depth %d)\n", job.url, outFile, job.depth)
+					for _, link := range links {
+						enqueue(link, job.depth+1)
+					}
+				}()
+			}
+		}(i)
+	}
+
+	pending.Add(1)
+	jobs <- mirrorJob{url: start.String(), depth: 0}
+	visited.Store(normalizeURL(start), "")
+
+	go func() {
+		pending.Wait()
+		close(jobs)
+	}()
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("mirror finished with %d error(s), first: %w", len(errs), errs[0])
+	}
+	return nil
+}
+
+// mirrorPage 渲染單一頁面、下載資產、改寫連結後寫入 outFile，回傳頁面上發現的連結供繼續爬取。
+func mirrorPage(ctx context.Context, pageURL string, outFile string) ([]string, error) {
+	processed, err := renderPage(ctx, pageURL, outFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var linkPairs [][]string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(collectLinksJS, &linkPairs)); err != nil {
+		return nil, fmt.Errorf("collect links: %w", err)
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]string, 0, len(linkPairs))
+	refs := make(map[string]string, len(linkPairs))
+	for _, pair := range linkPairs {
+		if len(pair) != 2 {
+			continue
+		}
+		raw, abs := pair[0], pair[1]
+		links = append(links, abs)
+		refs[raw] = mirrorPathFor(base, abs)
+	}
+	processed = rewriteLinks(processed, outFile, refs)
+
+	if err := os.MkdirAll(filepath.Dir(outFile), 0755); err != nil {
+		return nil, fmt.Errorf("mkdir %s: %w", filepath.Dir(outFile), err)
+	}
+	if err := os.WriteFile(outFile, []byte(gohtml.Format(processed)), 0644); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// mirrorPathFor 把絕對網址對應到 output/ 下保留原始路徑結構的本地檔案路徑。
+// 查詢字串會被折進檔名（見下方），因為 ?page=1 與 ?page=2 這類只差查詢字串的網址
+// 通常渲染結果不同，若直接忽略查詢字串，平行 worker 會把它們寫到同一個檔案互相覆蓋。
+func mirrorPathFor(base *url.URL, raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	if !u.IsAbs() {
+		u = base.ResolveReference(u)
+	}
+
+	p := u.EscapedPath()
+	if p == "" || strings.HasSuffix(p, "/") {
+		p = path.Join(p, "index.html")
+	} else if path.Ext(p) == "" {
+		p = p + "/index.html"
+	}
+
+	if u.RawQuery != "" {
+		ext := path.Ext(p)
+		hash := sha1.Sum([]byte(u.RawQuery))
+		p = strings.TrimSuffix(p, ext) + fmt.Sprintf("_q%x", hash)[:10] + ext
+	}
+
+	return filepath.Join("output", u.Host, filepath.FromSlash(strings.TrimPrefix(p, "/")))
+}
+
+// rewriteLinks 把 html 內容裡指向其他已爬取頁面的連結改成相對於 outFile 的本地路徑。
+// refs 以原始 href 屬性文字（非瀏覽器解析後的絕對網址）為 key，因為 outerHTML 序列化時
+// 保留的就是原始屬性文字，相對路徑不會被改寫成絕對路徑，對絕對網址做字串比對在相對連結
+// 這個最常見的情況下永遠找不到東西可換。只替換 href="原始文字" 這種完整屬性值，
+// 避免像 "/" 這類很短的原始 href 把文件裡其他地方的相同字串一起換掉。
+func rewriteLinks(html string, outFile string, refs map[string]string) string {
+	outDir := filepath.Dir(outFile)
+	for raw, localPath := range refs {
+		if localPath == "" {
+			continue
+		}
+		rel, err := filepath.Rel(outDir, localPath)
+		if err != nil {
+			continue
+		}
+		html = strings.ReplaceAll(html, `href="`+raw+`"`, `href="`+filepath.ToSlash(rel)+`"`)
+	}
+	return html
+}
+
+// normalizeURL 去掉 fragment 並統一結尾斜線，用於爬取去重。
+func normalizeURL(u *url.URL) string {
+	cp := *u
+	cp.Fragment = ""
+	s := cp.String()
+	return strings.TrimSuffix(s, "/")
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// sitemapURLEntry 對應 sitemap.xml 裡的一個 <url> 節點。
+type sitemapURLEntry struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+}
+
+// sitemapURLSet 是 sitemap.xml 的根節點 <urlset>。
+type sitemapURLSet struct {
+	XMLName xml.Name          `xml:"urlset"`
+	Xmlns   string            `xml:"xmlns,attr"`
+	URLs    []sitemapURLEntry `xml:"url"`
+}
+
+// sitemapConfig 控制 sitemap 模式的爬取與輸出行為，可由環境變數或 CLI flag 覆寫。
+type sitemapConfig struct {
+	workers    int
+	sameDomain bool
+	maxDepth   int
+	changeFreq string
+	resume     bool
+	resumeFile string
+}
+
+func loadSitemapConfig() sitemapConfig {
+	cfg := sitemapConfig{
+		workers:    envInt("WORKER_COUNT", 4),
+		sameDomain: envBool("SAME_DOMAIN", true),
+		maxDepth:   envInt("MAX_DEPTH", 3),
+		changeFreq: envOr("SITEMAP_CHANGEFREQ", "weekly"),
+		resume:     envBool("RESUME", false),
+		resumeFile: envOr("RESUME_FILE", filepath.Join("output", ".sitemap_resume.json")),
+	}
+
+	workers := flag.Int("workers", cfg.workers, "number of concurrent sitemap crawl workers")
+	maxDepth := flag.Int("max-depth", cfg.maxDepth, "maximum link-following depth from the start URL")
+	changeFreq := flag.String("changefreq", cfg.changeFreq, "sitemap <changefreq> value for every crawled URL")
+	resume := flag.Bool("resume", cfg.resume, "persist visited URLs so an interrupted crawl can continue")
+	resumeFile := flag.String("resume-file", cfg.resumeFile, "path to the JSON file used to persist visited URLs")
+	flag.Parse()
+
+	cfg.workers = *workers
+	cfg.maxDepth = *maxDepth
+	cfg.changeFreq = *changeFreq
+	cfg.resume = *resume
+	cfg.resumeFile = *resumeFile
+	return cfg
+}
+
+// sitemapJob 是 sitemap 爬取 worker pool 裡的一個工作單位。
+type sitemapJob struct {
+	url   string
+	depth int
+}
+
+// runSitemapCrawl 優先消費 TARGET_URL/sitemap.xml 既有的清單，找不到則從渲染後的 DOM 做同源 BFS 爬取，
+// 每個發現的網址都透過既有的 grabRenderedHTML 流程渲染落地，最後輸出 output/sitemap.xml 與 sitemap.txt。
+func runSitemapCrawl(allocCtx context.Context, startURL string, cfg sitemapConfig) error {
+	start, err := url.Parse(startURL)
+	if err != nil {
+		return fmt.Errorf("parse start URL: %w", err)
+	}
+
+	visited := loadSitemapCache(cfg)
+
+	var mu sync.Mutex
+	var entries []sitemapURLEntry
+
+	jobs := make(chan sitemapJob, 256)
+	var wg sync.WaitGroup
+	var pending sync.WaitGroup
+
+	enqueue := func(raw string, depth int) {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		if cfg.sameDomain && u.Host != start.Host {
+			return
+		}
+		if depth > cfg.maxDepth {
+			return
+		}
+		norm := normalizeURL(u)
+		if _, loaded := visited.LoadOrStore(norm, true); loaded {
+			return
+		}
+		pending.Add(1)
+		jobs <- sitemapJob{url: u.String(), depth: depth}
+	}
+
+	for i := 0; i < cfg.workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for job := range jobs {
+				func() {
+					defer pending.Done()
+					tabCtx, cancel := chromedp.NewContext(allocCtx)
+					defer cancel()
+					tabCtx, cancel2 := context.WithTimeout(tabCtx, 60*time.Second)
+					defer cancel2()
+
+					outFile := mirrorPathFor(start, job.url)
+					if err := grabRenderedHTML(tabCtx, job.url, outFile); err != nil {
+						log.Printf("[sitemap worker %d] %s: %v", worker, job.url, err)
+						return
+					}
+					fmt.Printf("[sitemap] rendered %s -> %s (depth %d)\n", job.url, outFile, job.depth)
+
+					lastmod := time.Now()
+					if info, err := os.Stat(outFile); err == nil {
+						lastmod = info.ModTime()
+					}
+					mu.Lock()
+					entries = append(entries, sitemapURLEntry{
+						Loc:        job.url,
+						LastMod:    lastmod.Format("2006-01-02"),
+						ChangeFreq: cfg.changeFreq,
+					})
+					mu.Unlock()
+
+					var linkPairs [][]string
+					if err := chromedp.Run(tabCtx, chromedp.Evaluate(collectLinksJS, &linkPairs)); err != nil {
+						log.Printf("[sitemap worker %d] collect links for %s: %v", worker, job.url, err)
+						return
+					}
+					for _, pair := range linkPairs {
+						if len(pair) != 2 {
+							continue
+						}
+						enqueue(pair[1], job.depth+1)
+					}
+				}()
+			}
+		}(i)
+	}
+
+	seeds, err := fetchExistingSitemap(startURL)
+	if err != nil {
+		log.Printf("[sitemap] no usable sitemap.xml at %s (%v), falling back to BFS crawl from %s", startURL, err, startURL)
+		seeds = []string{startURL}
+	} else {
+		fmt.Printf("[sitemap] seeded %d URL(s) from existing sitemap.xml\n", len(seeds))
+	}
+	for _, seed := range seeds {
+		enqueue(seed, 0)
+	}
+
+	go func() {
+		pending.Wait()
+		close(jobs)
+	}()
+	wg.Wait()
+
+	if cfg.resume {
+		if err := saveSitemapCache(cfg, visited); err != nil {
+			log.Printf("[sitemap] failed to persist resume cache: %v", err)
+		}
+	}
+
+	return writeSitemapFiles(entries)
+}
+
+// fetchExistingSitemap 嘗試讀取 startURL 路徑下的 sitemap.xml（startURL/sitemap.xml），回傳其中列出的網址。
+func fetchExistingSitemap(startURL string) ([]string, error) {
+	start, err := url.Parse(startURL)
+	if err != nil {
+		return nil, err
+	}
+	sitemapU := start.JoinPath("sitemap.xml")
+	sitemapU.RawQuery = ""
+	sitemapU.Fragment = ""
+	sitemapURL := sitemapU.String()
+
+	data, _, err := fetchBytes(sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var set sitemapURLSet
+	if err := xml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse sitemap.xml: %w", err)
+	}
+
+	locs := make([]string, 0, len(set.URLs))
+	for _, e := range set.URLs {
+		if e.Loc != "" {
+			locs = append(locs, e.Loc)
+		}
+	}
+	if len(locs) == 0 {
+		return nil, fmt.Errorf("sitemap.xml has no <url> entries")
+	}
+	return locs, nil
+}
+
+// writeSitemapFiles 把爬取結果寫成 output/sitemap.xml（urlset）與 output/sitemap.txt（純網址清單）。
+func writeSitemapFiles(entries []sitemapURLEntry) error {
+	set := sitemapURLSet{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		URLs:  entries,
+	}
+	body, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal sitemap.xml: %w", err)
+	}
+	xmlContent := append([]byte(xml.Header), body...)
+	if err := os.WriteFile(filepath.Join("output", "sitemap.xml"), xmlContent, 0644); err != nil {
+		return err
+	}
+
+	var txt strings.Builder
+	for _, e := range entries {
+		txt.WriteString(e.Loc)
+		txt.WriteString("\n")
+	}
+	if err := os.WriteFile(filepath.Join("output", "sitemap.txt"), []byte(txt.String()), 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("[sitemap] wrote %d URL(s) to output/sitemap.xml and output/sitemap.txt\n", len(entries))
+	return nil
+}
+
+// loadSitemapCache 在啟用 --resume 時，從 JSON 快取檔讀回上次已造訪的網址，讓中斷的爬取可以接續。
+func loadSitemapCache(cfg sitemapConfig) *sync.Map {
+	visited := &sync.Map{}
+	if !cfg.resume {
+		return visited
+	}
+
+	data, err := os.ReadFile(cfg.resumeFile)
+	if err != nil {
+		return visited
+	}
+
+	var urls []string
+	if err := json.Unmarshal(data, &urls); err != nil {
+		log.Printf("[sitemap] ignoring malformed resume cache %s: %v", cfg.resumeFile, err)
+		return visited
+	}
+	for _, u := range urls {
+		visited.Store(u, true)
+	}
+	fmt.Printf("[sitemap] resumed %d previously visited URL(s) from %s\n", len(urls), cfg.resumeFile)
+	return visited
+}
+
+// saveSitemapCache 把目前已造訪的網址寫回 JSON 快取檔，供下次 --resume 使用。
+func saveSitemapCache(cfg sitemapConfig, visited *sync.Map) error {
+	var urls []string
+	visited.Range(func(k, _ interface{}) bool {
+		urls = append(urls, k.(string))
+		return true
+	})
+
+	data, err := json.MarshalIndent(urls, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.resumeFile), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(cfg.resumeFile, data, 0644)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+var (
+	httpClientOnce sync.Once
+	httpClientInst *http.Client
+)
+
+// sharedHTTPClient 回傳套用了 HTTP_PROXY_URL / BASIC_AUTH_* / EXTRA_HEADERS 的共用 http.Client，
+// 供 downloadFile 與資產下載流水線使用，設定只在首次呼叫時讀取一次。
+func sharedHTTPClient() *http.Client {
+	httpClientOnce.Do(func() {
+		httpClientInst = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: loadTransportConfig().httpTransport(),
+		}
+	})
+	return httpClientInst
+}
+
+// transportConfig 收斂渲染端（chromedp）與下載端（http.Client）共用的代理、認證與標頭設定。
+type transportConfig struct {
+	proxyURL      string
+	basicUser     string
+	basicPass     string
+	cookieJarFile string
+	extraHeaders  map[string]string
+}
+
+// loadTransportConfig 從 .env 讀取 HTTP_PROXY_URL、BASIC_AUTH_USER/PASS、COOKIE_JAR_FILE、EXTRA_HEADERS。
+func loadTransportConfig() transportConfig {
+	cfg := transportConfig{
+		proxyURL:      os.Getenv("HTTP_PROXY_URL"),
+		basicUser:     os.Getenv("BASIC_AUTH_USER"),
+		basicPass:     os.Getenv("BASIC_AUTH_PASS"),
+		cookieJarFile: os.Getenv("COOKIE_JAR_FILE"),
+	}
+	if raw := os.Getenv("EXTRA_HEADERS"); raw != "" {
+		headers := map[string]string{}
+		if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+			log.Printf("EXTRA_HEADERS is not valid JSON, ignoring: %v", err)
+		} else {
+			cfg.extraHeaders = headers
+		}
+	}
+	return cfg
+}
+
+// allocatorOptions 回傳要套用到 chromedp ExecAllocator 的選項（目前只有代理伺服器）。
+func (cfg transportConfig) allocatorOptions() []chromedp.ExecAllocatorOption {
+	var opts []chromedp.ExecAllocatorOption
+	if cfg.proxyURL != "" {
+		opts = append(opts, chromedp.ProxyServer(cfg.proxyURL))
+	}
+	return opts
+}
+
+// headers 把 EXTRA_HEADERS 與 Basic Auth 合併成一份要送給 network.SetExtraHTTPHeaders 的標頭。
+func (cfg transportConfig) headers() network.Headers {
+	if len(cfg.extraHeaders) == 0 && cfg.basicUser == "" {
+		return nil
+	}
+	h := network.Headers{}
+	for k, v := range cfg.extraHeaders {
+		h[k] = v
+	}
+	if cfg.basicUser != "" {
+		token := base64.StdEncoding.EncodeToString([]byte(cfg.basicUser + ":" + cfg.basicPass))
+		h["Authorization"] = "Basic " + token
+	}
+	return h
+}
+
+// applyAction 回傳一個 chromedp action，在 network.Enable() 之後套用標頭與 COOKIE_JAR_FILE 裡的 cookies。
+func (cfg transportConfig) applyAction() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if h := cfg.headers(); h != nil {
+			if err := network.SetExtraHTTPHeaders(h).Do(ctx); err != nil {
+				return fmt.Errorf("set extra headers: %w", err)
+			}
+		}
+		if cfg.cookieJarFile == "" {
+			return nil
+		}
+		cookies, err := loadNetscapeCookieJar(cfg.cookieJarFile)
+		if err != nil {
+			log.Printf("cookie jar %s not loaded: %v", cfg.cookieJarFile, err)
+			return nil
+		}
+		if len(cookies) == 0 {
+			return nil
+		}
+		if err := network.SetCookies(cookies).Do(ctx); err != nil {
+			return fmt.Errorf("set cookies: %w", err)
+		}
+		return nil
+	})
+}
+
+// persistCookies 把渲染結束後目前頁面的 cookies 寫回 COOKIE_JAR_FILE，讓登入態可以在下次執行時重用。
+func (cfg transportConfig) persistCookies(ctx context.Context) error {
+	if cfg.cookieJarFile == "" {
+		return nil
+	}
+	var cookies []*network.Cookie
+	if err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		c, err := network.GetCookies().Do(ctx)
+		if err != nil {
+			return err
+		}
+		cookies = c
+		return nil
+	})); err != nil {
+		return fmt.Errorf("get cookies: %w", err)
+	}
+	return saveNetscapeCookieJar(cfg.cookieJarFile, cookies)
+}
+
+// httpTransport 依代理與自訂標頭組一個 http.RoundTripper，供 downloadFile 的 client 使用。
+func (cfg transportConfig) httpTransport() http.RoundTripper {
+	transport := &http.Transport{}
+	if cfg.proxyURL != "" {
+		if proxy, err := url.Parse(cfg.proxyURL); err == nil {
+			transport.Proxy = http.ProxyURL(proxy)
+		} else {
+			log.Printf("invalid HTTP_PROXY_URL %q, ignoring: %v", cfg.proxyURL, err)
+		}
+	}
+	return &headerRoundTripper{
+		base: transport,
+		cfg:  cfg,
+	}
+}
+
+// headerRoundTripper 在每個請求送出前加上 EXTRA_HEADERS 與 Basic Auth。
+type headerRoundTripper struct {
+	base http.RoundTripper
+	cfg  transportConfig
+}
+
+func (rt *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range rt.cfg.extraHeaders {
+		req.Header.Set(k, v)
+	}
+	if rt.cfg.basicUser != "" {
+		req.SetBasicAuth(rt.cfg.basicUser, rt.cfg.basicPass)
+	}
+	return rt.base.RoundTrip(req)
+}
+
+// loadNetscapeCookieJar 解析 Netscape cookies.txt 格式的檔案為 chromedp 可用的 CookieParam 清單。
+func loadNetscapeCookieJar(path string) ([]*network.CookieParam, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cookies []*network.CookieParam
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+		domain, _, cookiePath, secure, expires, name, value := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+
+		scheme := "http"
+		if strings.EqualFold(secure, "TRUE") {
+			scheme = "https"
+		}
+		cookies = append(cookies, &network.CookieParam{
+			Name:   name,
+			Value:  value,
+			Domain: strings.TrimPrefix(domain, "."),
+			Path:   cookiePath,
+			Secure: strings.EqualFold(secure, "TRUE"),
+			URL:    fmt.Sprintf("%s://%s%s", scheme, strings.TrimPrefix(domain, "."), cookiePath),
+		})
+		_ = expires // Netscape 格式的過期秒數，這裡沿用瀏覽器目前 session 的存續即可
+	}
+	return cookies, scanner.Err()
+}
+
+// saveNetscapeCookieJar 把 cookies 寫成 Netscape cookies.txt 格式，供下次執行時用 COOKIE_JAR_FILE 讀回。
+func saveNetscapeCookieJar(path string, cookies []*network.Cookie) error {
+	var b strings.Builder
+	b.WriteString("# Netscape HTTP Cookie File\n")
+	for _, c := range cookies {
+		domain := c.Domain
+		includeSubdomains := "FALSE"
+		if strings.HasPrefix(domain, ".") {
+			includeSubdomains = "TRUE"
+		}
+		secure := "FALSE"
+		if c.Secure {
+			secure = "TRUE"
+		}
+		expires := int64(0)
+		if !c.Session {
+			expires = int64(c.Expires)
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain, includeSubdomains, c.Path, secure, expires, c.Name, c.Value)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}